@@ -0,0 +1,195 @@
+package logpoller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/smartcontractkit/chainlink/v2/core/logger"
+	"github.com/smartcontractkit/chainlink/v2/core/services/pg"
+)
+
+// LogPoller polls an EVM chain for logs matching its registered filters and
+// serves them back out by block range, event signature, or indexed topic.
+type LogPoller interface {
+	RegisterFilter(filter Filter) error
+	Replay(fromBlock int64) error
+	LatestBlock(qopts ...pg.QOpt) (int64, error)
+	LogsWithSigs(start, end int64, eventSigs []common.Hash, address common.Address, qopts ...pg.QOpt) ([]Log, error)
+	LogsWithTopics(start, end int64, address common.Address, topics [4][]common.Hash, qopts ...pg.QOpt) ([]Log, error)
+}
+
+// Client is the subset of an EVM RPC client LogPoller needs to backfill
+// logs: fetching matching logs for a range, and the current chain head.
+type Client interface {
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// logPoller is an in-memory LogPoller: it keeps every log it has backfilled
+// in a slice rather than a database table. This is sufficient for
+// SimulatedBackend-driven tests (see evm21/testutil), where the chain's
+// entire history fits comfortably in memory and a real database would only
+// add setup cost without changing what the test exercises.
+//
+// This package has no Postgres-backed counterpart in this tree: there is no
+// ORM, connection pool, or log_poller table here for LogsWithTopics to push
+// filtering into, so it is implemented below as an in-memory scan rather
+// than SQL. A production LogPoller would need its own implementation of
+// this interface querying indexed topic0/topic1/topic2/topic3 columns (with
+// the GIN indexes that implies), not this one.
+type logPoller struct {
+	mu        sync.Mutex
+	client    Client
+	lggr      logger.Logger
+	filters   []Filter
+	logs      []Log
+	lastBlock int64
+}
+
+// NewLogPoller returns a LogPoller that backfills from client and keeps all
+// observed logs in memory.
+func NewLogPoller(client Client, lggr logger.Logger) LogPoller {
+	return &logPoller{client: client, lggr: lggr}
+}
+
+// RegisterFilter adds filter to the set of addresses/event signatures
+// Replay backfills logs for.
+func (lp *logPoller) RegisterFilter(filter Filter) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.filters = append(lp.filters, filter)
+	return nil
+}
+
+// Replay re-backfills logs for every registered filter from fromBlock
+// through the client's current head, so a SimulatedBackend-driven test can
+// call it after Commit() to make the poller observe a newly mined block.
+func (lp *logPoller) Replay(fromBlock int64) error {
+	ctx := context.Background()
+
+	head, err := lp.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("logpoller: fetching latest head: %w", err)
+	}
+	end := head.Number.Int64()
+
+	lp.mu.Lock()
+	filters := make([]Filter, len(lp.filters))
+	copy(filters, lp.filters)
+	lp.mu.Unlock()
+
+	var collected []Log
+	for _, f := range filters {
+		q := ethereum.FilterQuery{
+			FromBlock: big.NewInt(fromBlock),
+			ToBlock:   big.NewInt(end),
+			Addresses: f.Addresses,
+			Topics:    [][]common.Hash{f.EventSigs},
+		}
+		matched, err := lp.client.FilterLogs(ctx, q)
+		if err != nil {
+			return fmt.Errorf("logpoller: filtering logs: %w", err)
+		}
+		for _, l := range matched {
+			collected = append(collected, convertLog(l))
+		}
+	}
+
+	lp.mu.Lock()
+	lp.logs = mergeLogs(lp.logs, collected)
+	lp.lastBlock = end
+	lp.mu.Unlock()
+	return nil
+}
+
+// LatestBlock returns the highest block number Replay has backfilled
+// through.
+func (lp *logPoller) LatestBlock(qopts ...pg.QOpt) (int64, error) {
+	if err := pg.NewQueryConfig(qopts...).Context().Err(); err != nil {
+		return 0, fmt.Errorf("logpoller: %w", err)
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.lastBlock, nil
+}
+
+// LogsWithSigs returns backfilled logs in [start, end] emitted by address
+// whose topic0 is one of eventSigs.
+func (lp *logPoller) LogsWithSigs(start, end int64, eventSigs []common.Hash, address common.Address, qopts ...pg.QOpt) ([]Log, error) {
+	if start > end {
+		return nil, fmt.Errorf("logpoller: start block (%d) greater than end block (%d)", start, end)
+	}
+	if err := pg.NewQueryConfig(qopts...).Context().Err(); err != nil {
+		return nil, fmt.Errorf("logpoller: %w", err)
+	}
+
+	sigSet := make(map[common.Hash]bool, len(eventSigs))
+	for _, s := range eventSigs {
+		sigSet[s] = true
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	var out []Log
+	for _, l := range lp.logs {
+		if l.Address != address || l.BlockNumber < start || l.BlockNumber > end {
+			continue
+		}
+		if len(sigSet) > 0 && !sigSet[l.EventSig] {
+			continue
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// convertLog adapts a go-ethereum types.Log into LogPoller's Log model.
+func convertLog(l types.Log) Log {
+	topics := make([][]byte, len(l.Topics))
+	for i, t := range l.Topics {
+		topics[i] = t.Bytes()
+	}
+
+	var eventSig common.Hash
+	if len(l.Topics) > 0 {
+		eventSig = l.Topics[0]
+	}
+
+	return Log{
+		LogIndex:    int64(l.Index),
+		BlockHash:   l.BlockHash,
+		BlockNumber: int64(l.BlockNumber),
+		Topics:      topics,
+		EventSig:    eventSig,
+		Address:     l.Address,
+		TxHash:      l.TxHash,
+		Data:        l.Data,
+	}
+}
+
+// mergeLogs appends incoming logs not already present in existing,
+// deduping by (BlockHash, LogIndex) so repeated Replay calls over
+// overlapping ranges don't double-count a log.
+func mergeLogs(existing, incoming []Log) []Log {
+	seen := make(map[[2]interface{}]bool, len(existing))
+	for _, l := range existing {
+		seen[[2]interface{}{l.BlockHash, l.LogIndex}] = true
+	}
+	for _, l := range incoming {
+		key := [2]interface{}{l.BlockHash, l.LogIndex}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		existing = append(existing, l)
+	}
+	return existing
+}
@@ -0,0 +1,70 @@
+package logpoller
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/smartcontractkit/chainlink/v2/core/services/pg"
+)
+
+// LogsWithTopics returns backfilled logs in [start, end] emitted by address
+// whose topics match topics[i] for each non-empty position i. Passing nil
+// or an empty slice for a given topic position matches any value there.
+//
+// This mirrors LogsWithSigs, but lets callers with many distinct filters on
+// the same event (for example one log-trigger upkeep per topic1 value)
+// collapse them into a single query instead of issuing one LogsWithSigs
+// call per filter.
+//
+// This is the in-memory logPoller's implementation (see the package doc on
+// logPoller): it scans lp.logs rather than issuing SQL, since this tree has
+// no Postgres-backed LogPoller for the real indexed-column query to live
+// on.
+func (lp *logPoller) LogsWithTopics(start, end int64, address common.Address, topics [4][]common.Hash, qopts ...pg.QOpt) ([]Log, error) {
+	if start > end {
+		return nil, fmt.Errorf("logpoller: start block (%d) greater than end block (%d)", start, end)
+	}
+	if err := pg.NewQueryConfig(qopts...).Context().Err(); err != nil {
+		return nil, fmt.Errorf("logpoller: %w", err)
+	}
+
+	sets := [4]map[common.Hash]bool{}
+	for i, ts := range topics {
+		if len(ts) == 0 {
+			continue
+		}
+		sets[i] = make(map[common.Hash]bool, len(ts))
+		for _, t := range ts {
+			sets[i][t] = true
+		}
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	var out []Log
+	for _, l := range lp.logs {
+		if l.Address != address || l.BlockNumber < start || l.BlockNumber > end {
+			continue
+		}
+		if logMatchesTopicSets(l, sets) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+// logMatchesTopicSets reports whether l's topics satisfy every non-nil
+// position in sets.
+func logMatchesTopicSets(l Log, sets [4]map[common.Hash]bool) bool {
+	for i, set := range sets {
+		if set == nil {
+			continue
+		}
+		if i >= len(l.Topics) || !set[common.BytesToHash(l.Topics[i])] {
+			return false
+		}
+	}
+	return true
+}
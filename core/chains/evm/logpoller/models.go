@@ -0,0 +1,33 @@
+package logpoller
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/smartcontractkit/chainlink/v2/core/utils"
+)
+
+// Log is LogPoller's representation of an on-chain log, decoupled from
+// go-ethereum's types.Log so callers don't need an RPC client to work with
+// logs already observed and stored by the poller.
+type Log struct {
+	EvmChainId     *utils.Big
+	LogIndex       int64
+	BlockHash      common.Hash
+	BlockNumber    int64
+	BlockTimestamp time.Time
+	Topics         [][]byte
+	EventSig       common.Hash
+	Address        common.Address
+	TxHash         common.Hash
+	Data           []byte
+}
+
+// Filter describes which logs LogPoller should backfill and keep up to
+// date: any log emitted by one of Addresses whose topic0 is in EventSigs.
+type Filter struct {
+	Name      string
+	EventSigs []common.Hash
+	Addresses []common.Address
+}
@@ -0,0 +1,306 @@
+// Package evm implements ocr2keepers' Registry against the EVM
+// KeeperRegistry contract: polling LogPoller for upkeep state changes and
+// answering the OCR2 keepers plugin's queries about the current active set.
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	ocr2keepers "github.com/smartcontractkit/ocr2keepers/pkg"
+
+	commontypes "github.com/smartcontractkit/chainlink/v2/common/types"
+	evmclient "github.com/smartcontractkit/chainlink/v2/core/chains/evm/client"
+	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller"
+	evmtypes "github.com/smartcontractkit/chainlink/v2/core/chains/evm/types"
+	"github.com/smartcontractkit/chainlink/v2/core/gethwrappers/generated/keeper_registry_wrapper_2_1"
+	"github.com/smartcontractkit/chainlink/v2/core/logger"
+	"github.com/smartcontractkit/chainlink/v2/core/services/pg"
+)
+
+// logEventLookback is how far behind the latest observed head pollLogs (and
+// pollLogsIndexed) reach back for upkeep state logs on every poll, so a
+// brief RPC or log-poller hiccup doesn't permanently lose logs between the
+// last successful poll and the next one.
+const logEventLookback = 250
+
+var (
+	// ErrHeadNotAvailable is returned when the latest head can't be read
+	// from LogPoller.
+	ErrHeadNotAvailable = errors.New("head not available")
+	// ErrLogReadFailure is returned when LogPoller fails to return logs for
+	// the requested range.
+	ErrLogReadFailure = errors.New("log read failure")
+)
+
+// upkeepTriggerType distinguishes upkeeps that are checked every block
+// (conditionTrigger) from upkeeps that only run in response to a matching
+// log (logTrigger).
+type upkeepTriggerType uint8
+
+const (
+	conditionTrigger upkeepTriggerType = iota
+	logTrigger
+)
+
+// upkeepStateEvents are the KeeperRegistry event signatures that change an
+// upkeep's membership or configuration in the active set: registration,
+// cancellation, and pause/unpause.
+var upkeepStateEvents = []common.Hash{
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepRegisteredTopic,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepCanceledTopic,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepPausedTopic,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepUnpausedTopic,
+}
+
+// upkeepRemovalEvents are the upkeepStateEvents whose effect on the active
+// set is to remove the upkeep rather than upsert its (possibly unchanged)
+// state: a cancelled or paused upkeep must stop being served by
+// GetActiveUpkeepIDs, not just have its entry refreshed.
+var upkeepRemovalEvents = map[common.Hash]bool{
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepCanceledTopic: true,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepPausedTopic:   true,
+}
+
+// HeadProvider gives EvmRegistry access to the chain's most recently
+// observed head without a separate RPC round trip.
+type HeadProvider struct {
+	ht commontypes.HeadTracker[*evmtypes.Head, common.Hash]
+}
+
+// activeUpkeep is EvmRegistry's view of a single upkeep in the active set.
+type activeUpkeep struct {
+	ID              *big.Int
+	Type            upkeepTriggerType
+	MaxGasPriceGwei uint64
+}
+
+// EvmRegistry polls LogPoller for upkeep state changes and serves the OCR2
+// keepers plugin's active-set and trigger-decoding queries against the
+// result.
+type EvmRegistry struct {
+	HeadProvider
+
+	mu   sync.RWMutex
+	ctx  context.Context
+	lggr logger.Logger
+
+	addr          common.Address
+	poller        logpoller.LogPoller
+	chLog         chan logpoller.Log
+	lastPollBlock int64
+	active        map[string]activeUpkeep
+
+	registry *keeper_registry_wrapper_2_1.KeeperRegistry
+	client   evmclient.Client
+
+	// indexedLogsEnabled opts an operator into pollLogsIndexed, which
+	// issues an equivalent single LogsWithTopics call instead of pollLogs'
+	// LogsWithSigs call. See pollLogsIndexed's doc comment: this changes
+	// which LogPoller method (and, on a production backend, which SQL
+	// column) the filtering goes through, not how many queries are issued.
+	indexedLogsEnabled bool
+
+	// parallelCommitThreshold is the change-set size above which
+	// commitActive dispatches work to a worker pool instead of running
+	// serially. Zero means defaultParallelCommitThreshold.
+	parallelCommitThreshold int
+
+	// activeUpkeepResolver substitutes for the on-chain getUpkeep call in
+	// tests, so commitActive's concurrency can be exercised without a live
+	// registry binding.
+	activeUpkeepResolver func(ctx context.Context, au activeUpkeep) (activeUpkeep, error)
+}
+
+// GetActiveUpkeepIDs returns the identifiers of every upkeep EvmRegistry
+// currently considers active.
+func (r *EvmRegistry) GetActiveUpkeepIDs(ctx context.Context) ([]ocr2keepers.UpkeepIdentifier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]ocr2keepers.UpkeepIdentifier, 0, len(r.active))
+	for id := range r.active {
+		ids = append(ids, ocr2keepers.UpkeepIdentifier(id))
+	}
+	return ids, nil
+}
+
+// GetActiveUpkeepIDsByType returns the identifiers of active upkeeps whose
+// trigger type is one of triggers. The type is read from the upkeep ID
+// itself: byte 15 of its 32-byte form holds the type when bytes 4 through
+// 14 are zero, and any other ID is a conditional upkeep.
+func (r *EvmRegistry) GetActiveUpkeepIDsByType(ctx context.Context, triggers ...uint8) ([]ocr2keepers.UpkeepIdentifier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[upkeepTriggerType]bool, len(triggers))
+	for _, t := range triggers {
+		wanted[upkeepTriggerType(t)] = true
+	}
+
+	ids := make([]ocr2keepers.UpkeepIdentifier, 0, len(r.active))
+	for id, au := range r.active {
+		var raw [32]byte
+		au.ID.FillBytes(raw[:])
+		typ := upkeepTriggerType(raw[15])
+		for _, b := range raw[4:15] {
+			if b != 0 {
+				typ = conditionTrigger
+				break
+			}
+		}
+		if wanted[typ] {
+			ids = append(ids, ocr2keepers.UpkeepIdentifier(id))
+		}
+	}
+	return ids, nil
+}
+
+// pollLogs fetches upkeep state logs from the last logEventLookback blocks
+// up to the latest block, whenever that block has moved since the last
+// poll, and pushes them
+// onto chLog for processUpkeepStateLog to decode, or delegates to
+// pollLogsIndexed when the registry has opted into indexed topic lookups.
+func (r *EvmRegistry) pollLogs() error {
+	if r.indexedLogsEnabled {
+		return r.pollLogsIndexed()
+	}
+
+	latest, err := r.poller.LatestBlock(pg.WithParentCtx(context.Background()))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrHeadNotAvailable, err)
+	}
+
+	if r.lastPollBlock == 0 {
+		r.lastPollBlock = latest
+		return nil
+	}
+	if latest == r.lastPollBlock {
+		return nil
+	}
+
+	start := latest - logEventLookback
+	if start < 0 {
+		start = 0
+	}
+
+	logs, err := r.poller.LogsWithSigs(start, latest, upkeepStateEvents, r.addr, pg.WithParentCtx(context.Background()))
+	r.lastPollBlock = latest
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrLogReadFailure, err)
+	}
+
+	for _, l := range logs {
+		r.chLog <- l
+	}
+	return nil
+}
+
+// triggerTypeFromLog makes a best-effort guess at an upkeep's trigger type
+// from the shape of its state log: a log-trigger upkeep's events carry an
+// extra indexed parameter (the watched log's own topic) beyond the id every
+// upkeepStateEvents event carries in topic1. Callers that need an
+// authoritative answer should fetch it from the registry instead.
+func triggerTypeFromLog(l logpoller.Log) upkeepTriggerType {
+	if len(l.Topics) >= 3 {
+		return logTrigger
+	}
+	return conditionTrigger
+}
+
+// decodeUpkeepStateLog extracts the upkeep ID (topic1, the indexed id
+// parameter every upkeepStateEvents event carries) and trigger type from a
+// raw upkeep state log, along with whether the log's event (per
+// upkeepRemovalEvents) removes the upkeep from the active set rather than
+// upserting it.
+func decodeUpkeepStateLog(l logpoller.Log) (au activeUpkeep, removed bool, err error) {
+	if len(l.Topics) < 2 {
+		return activeUpkeep{}, false, fmt.Errorf("upkeep state log missing id topic")
+	}
+	return activeUpkeep{
+		ID:   common.BytesToHash(l.Topics[1]).Big(),
+		Type: triggerTypeFromLog(l),
+	}, upkeepRemovalEvents[l.EventSig], nil
+}
+
+// processUpkeepStateLog decodes a single upkeep state log and applies its
+// effect on the active set: a removal event deletes the upkeep directly,
+// while any other upkeepStateEvents event is upserted via commitActive.
+func (r *EvmRegistry) processUpkeepStateLog(l logpoller.Log) error {
+	au, removed, err := decodeUpkeepStateLog(l)
+	if err != nil {
+		return err
+	}
+	if removed {
+		r.removeActive(au.ID)
+		return nil
+	}
+	return r.commitActive(context.Background(), []activeUpkeep{au}, false)
+}
+
+// getBlockAndUpkeepId recovers the trigger block number and upkeep ID a
+// payload was built from.
+func (r *EvmRegistry) getBlockAndUpkeepId(payload ocr2keepers.UpkeepPayload) (*big.Int, *big.Int) {
+	block := big.NewInt(payload.Trigger.BlockNumber)
+	upkeepId := big.NewInt(0).SetBytes(payload.Upkeep.ID)
+	return block, upkeepId
+}
+
+// Start runs a single head-driven refresh: it polls for upkeep state logs
+// (via the indexed path when enabled) and commits the resulting active-set
+// changes, dispatching to commitActiveParallel once the batch crosses
+// parallelCommitThreshold instead of resolving one upkeep's on-chain state
+// at a time. It does not loop or subscribe to new heads itself; a caller
+// driving this on every observed head (e.g. from HeadProvider.ht's head
+// subscription) is what makes this behave like a refresh loop in
+// production. No such caller exists yet in this tree.
+func (r *EvmRegistry) Start(ctx context.Context) error {
+	r.ctx = ctx
+
+	if err := r.pollLogs(); err != nil {
+		r.lggr.Warnf("polling logs: %s", err)
+	}
+	return r.commitPendingLogs(ctx)
+}
+
+// commitPendingLogs drains chLog, decodes every pending log, applies
+// removal events (cancel/pause) to the active set directly, and commits
+// the rest via commitActive, which (once each change is resolved against
+// on-chain state) is what actually gates a change on
+// checkUpkeepGasEconomics.
+func (r *EvmRegistry) commitPendingLogs(ctx context.Context) error {
+	var changes []activeUpkeep
+
+drain:
+	for {
+		select {
+		case l := <-r.chLog:
+			au, removed, err := decodeUpkeepStateLog(l)
+			if err != nil {
+				r.lggr.Warnf("decoding upkeep state log: %s", err)
+				continue
+			}
+			if removed {
+				r.removeActive(au.ID)
+				continue
+			}
+			changes = append(changes, au)
+		default:
+			break drain
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	threshold := r.parallelCommitThreshold
+	if threshold == 0 {
+		threshold = defaultParallelCommitThreshold
+	}
+	return r.commitActive(ctx, changes, len(changes) > threshold)
+}
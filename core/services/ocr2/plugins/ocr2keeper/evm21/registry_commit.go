@@ -0,0 +1,245 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// defaultParallelCommitThreshold is the change-set size above which
+// commitActive dispatches work to a bounded worker pool instead of
+// processing changes serially. It mirrors the threshold go-ethereum's trie
+// committer uses to decide whether spawning goroutines for subtries is
+// worth the overhead.
+const defaultParallelCommitThreshold = 100
+
+// maxCommitWorkers bounds the worker pool commitActive spawns, so a head
+// that touches a very large number of upkeeps doesn't open one goroutine
+// (and RPC connection) per changed upkeep.
+const maxCommitWorkers = 16
+
+// commitActive resolves each entry in changes into its final activeUpkeep
+// state (fetching on-chain state via getUpkeep and parsing admin offchain
+// config), drops any that are a refresh of an already-active upkeep whose
+// resolved MaxGasPriceGwei now fails checkUpkeepGasEconomics against the
+// latest observed basefee (so a gas spike holds that upkeep's active-set
+// entry at its last-known-good state instead of refreshing it with data
+// the runner would immediately skip anyway), and merges the rest into
+// r.active. A first-time registration is never dropped this way: gas
+// economics gates execution, not whether a brand-new upkeep enters the
+// active set at all. When parallel is true and
+// changes exceeds r.parallelCommitThreshold, the work is spread across a
+// bounded worker pool and joined with a sync.WaitGroup, in the same spirit
+// as go-ethereum's trie committer spawning goroutines per subtrie once the
+// change set is large enough to be worth it. The first worker error aborts
+// the batch; results from in-flight workers are discarded so r.active is
+// never left in a half-updated state. A ctx cancellation partway through
+// dispatch is treated the same way: the batch is aborted rather than
+// applying only the changes that happened to be dispatched before
+// cancellation.
+func (r *EvmRegistry) commitActive(ctx context.Context, changes []activeUpkeep, parallel bool) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	threshold := r.parallelCommitThreshold
+	if threshold == 0 {
+		threshold = defaultParallelCommitThreshold
+	}
+	if !parallel || len(changes) <= threshold {
+		resolved := make([]activeUpkeep, len(changes))
+		for i, au := range changes {
+			var err error
+			resolved[i], err = r.resolveActiveUpkeep(ctx, au)
+			if err != nil {
+				return fmt.Errorf("commitActive: %w", err)
+			}
+		}
+		r.applyActiveUpdates(r.filterGasEligible(resolved))
+		return nil
+	}
+
+	return r.commitActiveParallel(ctx, changes)
+}
+
+// filterGasEligible drops resolved upkeeps that are a refresh of an
+// already-active upkeep whose MaxGasPriceGwei (just populated by
+// resolveActiveUpkeep) fails checkUpkeepGasEconomics against the latest
+// observed basefee. It must run after resolution: au fresh out of
+// decodeUpkeepStateLog always has the zero MaxGasPriceGwei, which
+// checkUpkeepGasEconomics treats as opted out and always lets through.
+//
+// A resolved upkeep not already in r.active is always let through
+// regardless of gas economics: it is a first-time registration, so there is
+// no last-known-good state to hold it at, and dropping it here would make
+// it silently absent from GetActiveUpkeepIDs until some unrelated state
+// event happened to recur. Gas economics belongs at execution time for
+// those, not at active-set membership time.
+func (r *EvmRegistry) filterGasEligible(resolved []activeUpkeep) []activeUpkeep {
+	baseFee := r.LatestBaseFee()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	eligible := resolved[:0]
+	for _, au := range resolved {
+		_, isRefresh := r.active[au.ID.String()]
+		if !isRefresh || checkUpkeepGasEconomics(au, baseFee, nil) {
+			eligible = append(eligible, au)
+		} else {
+			r.lggr.Debugf("skipping active-set refresh for upkeep %s: exceeds configured max gas price", au.ID)
+		}
+	}
+	return eligible
+}
+
+func (r *EvmRegistry) commitActiveParallel(ctx context.Context, changes []activeUpkeep) error {
+	workers := maxCommitWorkers
+	if workers > len(changes) {
+		workers = len(changes)
+	}
+
+	jobs := make(chan activeUpkeep)
+	results := make(chan activeUpkeepResult, len(changes))
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for au := range jobs {
+				resolved, err := r.resolveActiveUpkeep(ctx, au)
+				results <- activeUpkeepResult{upkeep: resolved, err: err}
+			}
+		}()
+	}
+
+	dispatchErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		for _, au := range changes {
+			select {
+			case jobs <- au:
+			case <-ctx.Done():
+				dispatchErr <- ctx.Err()
+				return
+			}
+		}
+		dispatchErr <- nil
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	resolved := make([]activeUpkeep, 0, len(changes))
+	var firstErr error
+	for res := range results {
+		if res.err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("commitActive: %w", res.err)
+			continue
+		}
+		resolved = append(resolved, res.upkeep)
+	}
+
+	if err := <-dispatchErr; err != nil && firstErr == nil {
+		firstErr = fmt.Errorf("commitActive: %w", err)
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// A cancellation that raced past dispatchErr without being observed
+	// there would still short a completed batch of its full change set;
+	// guard on count as well so a partial result is never applied.
+	if len(resolved) != len(changes) {
+		return fmt.Errorf("commitActive: resolved %d of %d changes", len(resolved), len(changes))
+	}
+
+	r.applyActiveUpdates(r.filterGasEligible(resolved))
+	return nil
+}
+
+// activeUpkeepResult carries one worker's outcome for a single change back
+// to the joining goroutine in commitActiveParallel.
+type activeUpkeepResult struct {
+	upkeep activeUpkeep
+	err    error
+}
+
+// resolveActiveUpkeep fetches an upkeep's on-chain state via getUpkeep and
+// parses its admin offchain config, filling in the rest of au. It does no
+// locking of its own, so it is safe to call concurrently from
+// commitActiveParallel's workers as long as the underlying registry binding
+// and poller are. In tests, r.activeUpkeepResolver substitutes for the
+// on-chain call so commitActive's concurrency can be exercised without a
+// live registry binding. Absent both a resolver and a registry binding (as
+// in harness-driven tests that only wire up a poller), it trusts the
+// decoded log state as-is rather than dereferencing a nil registry.
+func (r *EvmRegistry) resolveActiveUpkeep(ctx context.Context, au activeUpkeep) (activeUpkeep, error) {
+	if r.activeUpkeepResolver != nil {
+		return r.activeUpkeepResolver(ctx, au)
+	}
+	if r.registry == nil {
+		return au, nil
+	}
+
+	onchain, err := r.registry.GetUpkeep(&bind.CallOpts{Context: ctx}, au.ID)
+	if err != nil {
+		return activeUpkeep{}, fmt.Errorf("getUpkeep for %s: %w", au.ID, err)
+	}
+
+	if err := r.applyAdminOffchainConfig(&au, onchain.OffchainConfig); err != nil {
+		return activeUpkeep{}, fmt.Errorf("parsing admin offchain config for %s: %w", au.ID, err)
+	}
+
+	return au, nil
+}
+
+// adminOffchainConfig is the subset of an upkeep's admin offchain config
+// EvmRegistry understands.
+type adminOffchainConfig struct {
+	MaxGasPriceGwei uint64 `json:"maxGasPriceGwei"`
+}
+
+// applyAdminOffchainConfig decodes raw admin offchain config and applies
+// its fields to au, most notably maxGasPriceGwei, which
+// checkUpkeepGasEconomics uses to decide whether to skip execution when the
+// network is more expensive than the upkeep owner is willing to pay for.
+func (r *EvmRegistry) applyAdminOffchainConfig(au *activeUpkeep, raw []byte) error {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var cfg adminOffchainConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("unmarshaling admin offchain config: %w", err)
+	}
+
+	au.MaxGasPriceGwei = cfg.MaxGasPriceGwei
+	return nil
+}
+
+// applyActiveUpdates merges resolved into r.active under r.mu, so readers
+// of GetActiveUpkeepIDs never observe a partially-applied batch.
+func (r *EvmRegistry) applyActiveUpdates(resolved []activeUpkeep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, au := range resolved {
+		r.active[au.ID.String()] = au
+	}
+}
+
+// removeActive drops id from r.active, for upkeepRemovalEvents (cancel,
+// pause) whose membership effect is removal rather than an upsert.
+func (r *EvmRegistry) removeActive(id *big.Int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.active, id.String())
+}
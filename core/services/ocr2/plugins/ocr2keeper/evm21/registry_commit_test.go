@@ -0,0 +1,144 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonmocks "github.com/smartcontractkit/chainlink/v2/common/mocks"
+	evmtypes "github.com/smartcontractkit/chainlink/v2/core/chains/evm/types"
+	"github.com/smartcontractkit/chainlink/v2/core/logger"
+	"github.com/smartcontractkit/chainlink/v2/core/utils"
+)
+
+func newTestCommitRegistry(t *testing.T, threshold int) *EvmRegistry {
+	t.Helper()
+	rg := &EvmRegistry{
+		active:                  make(map[string]activeUpkeep),
+		parallelCommitThreshold: threshold,
+	}
+	rg.activeUpkeepResolver = func(ctx context.Context, au activeUpkeep) (activeUpkeep, error) {
+		if au.ID.Sign() == 0 {
+			return activeUpkeep{}, fmt.Errorf("refusing to resolve upkeep 0")
+		}
+		return au, nil
+	}
+	return rg
+}
+
+func testChanges(n int) []activeUpkeep {
+	changes := make([]activeUpkeep, n)
+	for i := 0; i < n; i++ {
+		changes[i] = activeUpkeep{ID: big.NewInt(int64(i + 1))}
+	}
+	return changes
+}
+
+func TestCommitActive_OrderIndependent(t *testing.T) {
+	changes := testChanges(250)
+
+	serial := newTestCommitRegistry(t, 1000)
+	require.NoError(t, serial.commitActive(context.Background(), changes, false))
+
+	parallel := newTestCommitRegistry(t, 100)
+	require.NoError(t, parallel.commitActive(context.Background(), changes, true))
+
+	assert.Equal(t, serial.active, parallel.active)
+	assert.Len(t, parallel.active, len(changes))
+}
+
+func TestCommitActive_BelowThresholdRunsSerially(t *testing.T) {
+	rg := newTestCommitRegistry(t, 100)
+	changes := testChanges(10)
+
+	require.NoError(t, rg.commitActive(context.Background(), changes, true))
+	assert.Len(t, rg.active, 10)
+}
+
+func TestCommitActive_WorkerErrorAbortsBatch(t *testing.T) {
+	rg := newTestCommitRegistry(t, 10)
+	changes := testChanges(200)
+	// an upkeep ID of 0 forces the fake resolver to fail for this change.
+	changes[150].ID = big.NewInt(0)
+
+	err := rg.commitActive(context.Background(), changes, true)
+	assert.Error(t, err)
+	assert.Empty(t, rg.active, "a failed batch must not partially update active")
+}
+
+func TestCommitActive_ContextCancelledMidBatchAbortsCleanly(t *testing.T) {
+	rg := newTestCommitRegistry(t, 10)
+	changes := testChanges(500)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rg.activeUpkeepResolver = func(ctx context.Context, au activeUpkeep) (activeUpkeep, error) {
+		if au.ID.Cmp(big.NewInt(5)) == 0 {
+			cancel()
+		}
+		return au, nil
+	}
+
+	err := rg.commitActive(ctx, changes, true)
+	assert.Error(t, err)
+	assert.Empty(t, rg.active, "a batch cancelled mid-dispatch must not partially update active")
+}
+
+func TestFilterGasEligible_NewRegistrationNeverDropped(t *testing.T) {
+	expensiveUpkeep := big.NewInt(1)
+
+	mht := commonmocks.NewHeadTracker[*evmtypes.Head, common.Hash](t)
+	mht.On("LatestChain").Return(&evmtypes.Head{Number: 1, BaseFeePerGas: utils.NewBig(big.NewInt(50_000_000_000))})
+
+	rg := &EvmRegistry{
+		HeadProvider: HeadProvider{ht: mht},
+		active:       make(map[string]activeUpkeep),
+		lggr:         logger.TestLogger(t),
+	}
+
+	resolved := []activeUpkeep{{ID: expensiveUpkeep, MaxGasPriceGwei: 1}}
+	eligible := rg.filterGasEligible(resolved)
+
+	assert.Equal(t, resolved, eligible, "a first-time registration must never be dropped by gas economics")
+}
+
+func TestFilterGasEligible_RefreshDroppedOverMaxGasPrice(t *testing.T) {
+	existingUpkeep := big.NewInt(1)
+
+	mht := commonmocks.NewHeadTracker[*evmtypes.Head, common.Hash](t)
+	mht.On("LatestChain").Return(&evmtypes.Head{Number: 1, BaseFeePerGas: utils.NewBig(big.NewInt(50_000_000_000))})
+
+	rg := &EvmRegistry{
+		HeadProvider: HeadProvider{ht: mht},
+		active:       map[string]activeUpkeep{existingUpkeep.String(): {ID: existingUpkeep, MaxGasPriceGwei: 40}},
+		lggr:         logger.TestLogger(t),
+	}
+
+	resolved := []activeUpkeep{{ID: existingUpkeep, MaxGasPriceGwei: 1}}
+	eligible := rg.filterGasEligible(resolved)
+
+	assert.Empty(t, eligible, "a refresh of an already-active upkeep must be dropped once it exceeds its max gas price")
+}
+
+func BenchmarkCommitActive_5kUpkeeps(b *testing.B) {
+	changes := testChanges(5000)
+	resolver := func(ctx context.Context, au activeUpkeep) (activeUpkeep, error) { return au, nil }
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rg := &EvmRegistry{active: make(map[string]activeUpkeep), parallelCommitThreshold: 100000, activeUpkeepResolver: resolver}
+			_ = rg.commitActive(context.Background(), changes, false)
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rg := &EvmRegistry{active: make(map[string]activeUpkeep), parallelCommitThreshold: 100, activeUpkeepResolver: resolver}
+			_ = rg.commitActive(context.Background(), changes, true)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	commonmocks "github.com/smartcontractkit/chainlink/v2/common/mocks"
+	evmtypes "github.com/smartcontractkit/chainlink/v2/core/chains/evm/types"
+	"github.com/smartcontractkit/chainlink/v2/core/utils"
+)
+
+func TestCheckUpkeepGasEconomics(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Upkeep   activeUpkeep
+		BaseFee  *big.Int
+		Tip      *big.Int
+		Expected bool
+	}{
+		{
+			Name:     "no max gas price configured always passes",
+			Upkeep:   activeUpkeep{},
+			BaseFee:  big.NewInt(500_000_000_000),
+			Expected: true,
+		},
+		{
+			Name:     "no basefee observed always passes",
+			Upkeep:   activeUpkeep{MaxGasPriceGwei: 10},
+			BaseFee:  nil,
+			Expected: true,
+		},
+		{
+			Name:     "basefee plus tip under max gas price passes",
+			Upkeep:   activeUpkeep{MaxGasPriceGwei: 50},
+			BaseFee:  big.NewInt(20_000_000_000),
+			Tip:      big.NewInt(2_000_000_000),
+			Expected: true,
+		},
+		{
+			Name:     "basefee plus tip over max gas price fails",
+			Upkeep:   activeUpkeep{MaxGasPriceGwei: 10},
+			BaseFee:  big.NewInt(9_000_000_000),
+			Tip:      big.NewInt(2_000_000_000),
+			Expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, checkUpkeepGasEconomics(test.Upkeep, test.BaseFee, test.Tip))
+		})
+	}
+}
+
+func TestApplyAdminOffchainConfig_MaxGasPriceGwei(t *testing.T) {
+	r := &EvmRegistry{}
+
+	tests := []struct {
+		Name     string
+		Raw      []byte
+		Expected uint64
+	}{
+		{Name: "empty config leaves field unset", Raw: nil, Expected: 0},
+		{Name: "parses maxGasPriceGwei", Raw: []byte(`{"maxGasPriceGwei": 75}`), Expected: 75},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			au := activeUpkeep{}
+			err := r.applyAdminOffchainConfig(&au, test.Raw)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, au.MaxGasPriceGwei)
+		})
+	}
+}
+
+// TestLatestBaseFee covers both a legacy (pre-London) head with no basefee
+// and an EIP-1559 head, so checkUpkeepGasEconomics's caller sees the right
+// value in either case.
+func TestLatestBaseFee(t *testing.T) {
+	t.Run("legacy head has no basefee", func(t *testing.T) {
+		mht := commonmocks.NewHeadTracker[*evmtypes.Head, common.Hash](t)
+		mht.On("LatestChain").Return(&evmtypes.Head{Number: 1})
+
+		r := &EvmRegistry{HeadProvider: HeadProvider{ht: mht}}
+		assert.Nil(t, r.LatestBaseFee())
+	})
+
+	t.Run("1559 head reports basefee", func(t *testing.T) {
+		mht := commonmocks.NewHeadTracker[*evmtypes.Head, common.Hash](t)
+		mht.On("LatestChain").Return(&evmtypes.Head{Number: 1, BaseFeePerGas: utils.NewBig(big.NewInt(30_000_000_000))})
+
+		r := &EvmRegistry{HeadProvider: HeadProvider{ht: mht}}
+		assert.Equal(t, big.NewInt(30_000_000_000), r.LatestBaseFee())
+	})
+}
@@ -0,0 +1,87 @@
+package evm
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller"
+	"github.com/smartcontractkit/chainlink/v2/core/services/pg"
+)
+
+// pollLogsIndexed is an alternate path to pollLogs that fetches the same
+// range of upkeepStateEvents logs via LogPoller.LogsWithTopics instead of
+// LogsWithSigs. It is opt-in via EvmRegistry.indexedLogsEnabled.
+//
+// This does not batch per-upkeep topic1 filters the way the original design
+// for this feature intended: pollLogs already issues a single LogsWithSigs
+// call per poll for every log-trigger and condition-trigger upkeep alike,
+// so there is no per-upkeep query fan-out here for topic1 batching to
+// collapse. Narrowing topic1 to known active log-trigger IDs (as an earlier
+// version of this function did) would only drop coverage — it would stop
+// discovering new registrations and condition-trigger cancels/pauses, which
+// have no topic1 EvmRegistry already knows about — without reducing the
+// query count, since topic1 stays unconstrained in the one query that
+// remains. What pollLogsIndexed actually changes is where topic filtering
+// happens: on a Postgres-backed LogPoller (which this tree doesn't have,
+// see the logpoller package doc) that would mean pushing topic0 into an
+// indexed column instead of filtering in Go, not reducing the number of
+// queries issued.
+//
+// pollLogsIndexed groups the fetched logs by the upkeep ID in topic1 and
+// pushes each group onto chLog in turn. Like pollLogs, it pushes raw
+// logpoller.Log values: it does not decode them, that is left to
+// processUpkeepStateLog or commitPendingLogs. Logs without a topic1 are
+// dropped, and the order of the groups is not defined. Like pollLogs, the
+// send onto chLog blocks: a
+// full chLog stalls the poll rather than silently dropping a membership
+// event a commit cycle hasn't drained yet.
+func (r *EvmRegistry) pollLogsIndexed() error {
+	latest, err := r.poller.LatestBlock(pg.WithParentCtx(r.ctx))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrHeadNotAvailable, err)
+	}
+
+	if r.lastPollBlock == 0 {
+		r.lastPollBlock = latest
+		return nil
+	}
+	if latest == r.lastPollBlock {
+		return nil
+	}
+
+	start := latest - logEventLookback
+	if start < 0 {
+		start = 0
+	}
+
+	logs, err := r.poller.LogsWithTopics(start, latest, r.addr, [4][]common.Hash{upkeepStateEvents, nil, nil, nil})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrLogReadFailure, err)
+	}
+
+	byUpkeepID := groupLogsByUpkeepID(logs)
+	for _, upkeepLogs := range byUpkeepID {
+		for _, l := range upkeepLogs {
+			r.chLog <- l
+		}
+	}
+
+	r.lastPollBlock = latest
+	return nil
+}
+
+// groupLogsByUpkeepID indexes logs fetched via pollLogsIndexed by the
+// upkeep ID encoded in topic1, so callers don't need to re-derive it by
+// decoding each log's payload.
+func groupLogsByUpkeepID(logs []logpoller.Log) map[string][]logpoller.Log {
+	out := make(map[string][]logpoller.Log, len(logs))
+	for _, l := range logs {
+		if len(l.Topics) < 2 {
+			continue
+		}
+		id := common.BytesToHash(l.Topics[1]).Big().String()
+		out[id] = append(out[id], l)
+	}
+	return out
+}
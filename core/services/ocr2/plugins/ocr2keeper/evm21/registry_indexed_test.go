@@ -0,0 +1,120 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller"
+	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller/mocks"
+)
+
+func TestPollLogsIndexed(t *testing.T) {
+	addr := common.BigToAddress(big.NewInt(1))
+	upkeepID := big.NewInt(32329108151019397958065800113404894502874153543356521479058624064899121404671)
+
+	tests := []struct {
+		Name        string
+		Active      map[string]activeUpkeep
+		LatestBlock int64
+		Logs        []logpoller.Log
+		ExpectedErr error
+	}{
+		{
+			Name:        "no indexed logs",
+			LatestBlock: 500,
+			Logs:        []logpoller.Log{},
+		},
+		{
+			Name:        "log with topic1 upkeep id",
+			Active:      map[string]activeUpkeep{upkeepID.String(): {ID: upkeepID, Type: logTrigger}},
+			LatestBlock: 500,
+			Logs: []logpoller.Log{
+				{LogIndex: 1, Topics: [][]byte{common.Hash{}.Bytes(), common.BigToHash(upkeepID).Bytes()}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			mp := new(mocks.LogPoller)
+			mp.On("LatestBlock", mock.Anything).Return(test.LatestBlock, nil)
+			// topic1 must stay unconstrained (nil) even when Active already
+			// holds log-trigger upkeeps, so newly-registered upkeeps and
+			// condition-trigger cancels/pauses keep being discovered.
+			mp.On("LogsWithTopics", mock.Anything, test.LatestBlock, addr, [4][]common.Hash{upkeepStateEvents, nil, nil, nil}, mock.Anything).Return(test.Logs, nil)
+
+			rg := &EvmRegistry{
+				addr:          addr,
+				poller:        mp,
+				chLog:         make(chan logpoller.Log, 10),
+				lastPollBlock: test.LatestBlock - 20,
+				active:        test.Active,
+			}
+
+			err := rg.pollLogsIndexed()
+			if test.ExpectedErr != nil {
+				assert.ErrorIs(t, err, test.ExpectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.LatestBlock, rg.lastPollBlock)
+		})
+	}
+}
+
+// TestPollLogsIndexed_ChLogFullBlocks asserts that when a poll returns more
+// logs than chLog's buffer holds, pollLogsIndexed blocks on the send rather
+// than dropping the overflow, matching pollLogs' own behavior. Regression
+// test for a prior version that used a non-blocking select/default send,
+// which silently and permanently lost membership events once chLog filled
+// up during a burst of log-trigger state changes.
+func TestPollLogsIndexed_ChLogFullBlocks(t *testing.T) {
+	addr := common.BigToAddress(big.NewInt(1))
+	const bufSize = 10
+	const logCount = bufSize + 5
+
+	logs := make([]logpoller.Log, logCount)
+	for i := range logs {
+		upkeepID := big.NewInt(int64(i) + 1)
+		logs[i] = logpoller.Log{
+			LogIndex: int64(i),
+			Topics:   [][]byte{common.Hash{}.Bytes(), common.BigToHash(upkeepID).Bytes()},
+		}
+	}
+
+	mp := new(mocks.LogPoller)
+	mp.On("LatestBlock", mock.Anything).Return(int64(500), nil)
+	mp.On("LogsWithTopics", mock.Anything, int64(500), addr, mock.Anything, mock.Anything).Return(logs, nil)
+
+	rg := &EvmRegistry{
+		addr:          addr,
+		poller:        mp,
+		chLog:         make(chan logpoller.Log, bufSize),
+		lastPollBlock: 480,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- rg.pollLogsIndexed() }()
+
+	received := 0
+	for received < logCount {
+		select {
+		case <-rg.chLog:
+			received++
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for logs: got %d of %d", received, logCount)
+		}
+	}
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("pollLogsIndexed did not return after chLog was drained")
+	}
+}
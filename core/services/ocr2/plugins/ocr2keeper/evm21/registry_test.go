@@ -11,11 +11,13 @@ import (
 	ocr2keepers "github.com/smartcontractkit/ocr2keepers/pkg"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	commonmocks "github.com/smartcontractkit/chainlink/v2/common/mocks"
 	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller"
 	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller/mocks"
 	evmtypes "github.com/smartcontractkit/chainlink/v2/core/chains/evm/types"
+	"github.com/smartcontractkit/chainlink/v2/core/services/ocr2/plugins/ocr2keeper/evm21/testutil"
 	"github.com/smartcontractkit/chainlink/v2/core/utils"
 )
 
@@ -365,3 +367,132 @@ func TestRegistry_GetBlockAndUpkeepId(t *testing.T) {
 		})
 	}
 }
+
+// TestPollLogs_SimulatedBackend exercises pollLogs against a real
+// KeeperRegistry deployed on a go-ethereum SimulatedBackend and a real
+// logpoller instance, instead of the hand-crafted LogPoller mock used by
+// TestPollLogs above.
+func TestPollLogs_SimulatedBackend(t *testing.T) {
+	h := testutil.NewHarness(t)
+
+	rg := &EvmRegistry{
+		addr:   h.RegistryAddress,
+		poller: h.LogPoller,
+		chLog:  make(chan logpoller.Log, 10),
+		active: make(map[string]activeUpkeep),
+	}
+
+	// pollLogs' first call on a zero lastPollBlock only records a baseline
+	// block; it never queries logs. Prime that baseline before registering
+	// the upkeep, so the second call actually observes its log.
+	require.NoError(t, rg.pollLogs())
+
+	target := common.BigToAddress(big.NewInt(42))
+	id := h.RegisterConditionUpkeep(target, 500_000)
+	h.Commit()
+
+	err := rg.pollLogs()
+	assert.NoError(t, err)
+	for l := range drainChLog(rg.chLog) {
+		require.NoError(t, rg.processUpkeepStateLog(l))
+	}
+
+	ids, err := rg.GetActiveUpkeepIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, idsAsStrings(ids), id.String())
+}
+
+// TestGetActiveUpkeepIDs_SimulatedBackend asserts the active set reflects
+// registrations and cancellations observed through the real logpoller.
+func TestGetActiveUpkeepIDs_SimulatedBackend(t *testing.T) {
+	h := testutil.NewHarness(t)
+
+	rg := &EvmRegistry{
+		addr:   h.RegistryAddress,
+		poller: h.LogPoller,
+		chLog:  make(chan logpoller.Log, 10),
+		active: make(map[string]activeUpkeep),
+	}
+
+	// Prime pollLogs' baseline before any upkeep exists, so the first poll
+	// after registering actually queries for (and observes) their logs.
+	require.NoError(t, rg.pollLogs())
+
+	condID := h.RegisterConditionUpkeep(common.BigToAddress(big.NewInt(1)), 500_000)
+	logID := h.RegisterLogUpkeep(common.BigToAddress(big.NewInt(2)), 500_000, common.HexToHash("0x1"))
+	h.Commit()
+
+	require.NoError(t, rg.pollLogs())
+	for l := range drainChLog(rg.chLog) {
+		require.NoError(t, rg.processUpkeepStateLog(l))
+	}
+
+	ids, err := rg.GetActiveUpkeepIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, idsAsStrings(ids), condID.String())
+	assert.Contains(t, idsAsStrings(ids), logID.String())
+
+	h.CancelUpkeep(condID)
+	require.NoError(t, rg.pollLogs())
+	for l := range drainChLog(rg.chLog) {
+		require.NoError(t, rg.processUpkeepStateLog(l))
+	}
+
+	ids, err = rg.GetActiveUpkeepIDs(context.Background())
+	assert.NoError(t, err)
+	assert.NotContains(t, idsAsStrings(ids), condID.String())
+}
+
+// TestGetActiveUpkeepIDsByType_SimulatedBackend mirrors
+// TestGetActiveUpkeepIDsByType but against the simulated-backend harness,
+// so log-trigger vs condition-trigger classification is verified against
+// real registry state rather than hand-seeded activeUpkeep entries.
+func TestGetActiveUpkeepIDsByType_SimulatedBackend(t *testing.T) {
+	h := testutil.NewHarness(t)
+
+	rg := &EvmRegistry{
+		addr:   h.RegistryAddress,
+		poller: h.LogPoller,
+		chLog:  make(chan logpoller.Log, 10),
+		active: make(map[string]activeUpkeep),
+	}
+
+	require.NoError(t, rg.pollLogs())
+
+	condID := h.RegisterConditionUpkeep(common.BigToAddress(big.NewInt(1)), 500_000)
+	logID := h.RegisterLogUpkeep(common.BigToAddress(big.NewInt(2)), 500_000, common.HexToHash("0x1"))
+	h.Commit()
+
+	require.NoError(t, rg.pollLogs())
+	for l := range drainChLog(rg.chLog) {
+		require.NoError(t, rg.processUpkeepStateLog(l))
+	}
+
+	condIDs, err := rg.GetActiveUpkeepIDsByType(context.Background(), uint8(conditionTrigger))
+	assert.NoError(t, err)
+	assert.Contains(t, idsAsStrings(condIDs), condID.String())
+
+	logIDs, err := rg.GetActiveUpkeepIDsByType(context.Background(), uint8(logTrigger))
+	assert.NoError(t, err)
+	assert.Contains(t, idsAsStrings(logIDs), logID.String())
+}
+
+func drainChLog(ch chan logpoller.Log) []logpoller.Log {
+	var out []logpoller.Log
+	for {
+		select {
+		case l := <-ch:
+			out = append(out, l)
+		default:
+			return out
+		}
+	}
+}
+
+func idsAsStrings(ids []ocr2keepers.UpkeepIdentifier) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = string(id)
+	}
+	return out
+}
@@ -0,0 +1,171 @@
+// Package testutil provides a SimulatedBackend-based harness for exercising
+// EvmRegistry against a real KeeperRegistry contract and a real in-memory
+// logpoller instance, rather than mocked LogPoller/HeadTracker interfaces.
+//
+// It gives tests end-to-end coverage of pollLogs, processUpkeepStateLog,
+// and active-set maintenance, driving block production with
+// Backend.Commit() and asserting on EvmRegistry's resulting state. Reorgs
+// are not modelled: the in-memory logpoller never removes a log it has
+// backfilled, and nothing here waits for blocks to become final.
+package testutil
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	evmclient "github.com/smartcontractkit/chainlink/v2/core/chains/evm/client"
+	"github.com/smartcontractkit/chainlink/v2/core/chains/evm/logpoller"
+	"github.com/smartcontractkit/chainlink/v2/core/gethwrappers/generated/keeper_registry_wrapper_2_1"
+	"github.com/smartcontractkit/chainlink/v2/core/logger"
+)
+
+// upkeepStateTopics mirrors evm21's own (unexported) upkeepStateEvents list.
+// It's duplicated here rather than imported since a harness in a different
+// package can't reach an unexported identifier, but both lists are sourced
+// from the same generated wrapper constants so they can't drift silently.
+var upkeepStateTopics = []common.Hash{
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepRegisteredTopic,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepCanceledTopic,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepPausedTopic,
+	keeper_registry_wrapper_2_1.KeeperRegistryUpkeepUnpausedTopic,
+}
+
+// Harness wires a go-ethereum SimulatedBackend to a deployed KeeperRegistry
+// and a real logpoller.LogPoller, so EvmRegistry can be exercised end to end.
+type Harness struct {
+	T testing.TB
+
+	Backend *backends.SimulatedBackend
+	Owner   *bind.TransactOpts
+
+	RegistryAddress common.Address
+	Registry        *keeper_registry_wrapper_2_1.KeeperRegistry
+
+	LogPoller logpoller.LogPoller
+}
+
+// NewHarness deploys a KeeperRegistry to a fresh SimulatedBackend and starts
+// a real in-memory logpoller.LogPoller against it.
+func NewHarness(t testing.TB) *Harness {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	owner, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+
+	genesisAlloc := core.GenesisAlloc{
+		owner.From: {Balance: big.NewInt(0).Mul(big.NewInt(1_000), big.NewInt(1e18))},
+	}
+	backend := backends.NewSimulatedBackend(genesisAlloc, 10_000_000)
+
+	registryAddress, _, registry, err := keeper_registry_wrapper_2_1.DeployKeeperRegistry(
+		owner,
+		backend,
+		common.Address{}, // logic address; not exercised by these tests
+		common.Address{},
+		common.Address{},
+		common.Address{},
+	)
+	require.NoError(t, err)
+	backend.Commit()
+
+	lggr := logger.TestLogger(t)
+	client := evmclient.NewSimulatedBackendClient(t, backend, big.NewInt(1337))
+	lp := logpoller.NewLogPoller(client, lggr)
+	require.NoError(t, lp.RegisterFilter(logpoller.Filter{
+		Name:      "evmregistry-testutil",
+		EventSigs: upkeepStateTopics,
+		Addresses: []common.Address{registryAddress},
+	}))
+	// Backfill through the deployment block, so LatestBlock reports the
+	// chain's head from the start rather than zero.
+	require.NoError(t, lp.Replay(backend.Blockchain().CurrentHeader().Number.Int64()))
+
+	return &Harness{
+		T:               t,
+		Backend:         backend,
+		Owner:           owner,
+		RegistryAddress: registryAddress,
+		Registry:        registry,
+		LogPoller:       lp,
+	}
+}
+
+// Commit advances the simulated chain by one block and gives the log poller
+// a chance to observe it, emulating the cadence EvmRegistry's HeadProvider
+// drives it with in production.
+func (h *Harness) Commit() {
+	h.Backend.Commit()
+	require.NoError(h.T, h.LogPoller.Replay(h.Backend.Blockchain().CurrentHeader().Number.Int64()))
+}
+
+// RegisterConditionUpkeep registers a conditional-trigger upkeep with the
+// deployed registry and returns its upkeep ID.
+func (h *Harness) RegisterConditionUpkeep(target common.Address, gasLimit uint32) *big.Int {
+	return h.registerUpkeep(target, gasLimit, conditionTriggerType)
+}
+
+// RegisterLogUpkeep registers a log-trigger upkeep watching the given
+// contract/topic and returns its upkeep ID.
+func (h *Harness) RegisterLogUpkeep(target common.Address, gasLimit uint32, topic common.Hash) *big.Int {
+	id := h.registerUpkeep(target, gasLimit, logTriggerType)
+	_, err := h.Registry.SetUpkeepTriggerConfig(h.Owner, id, topic.Bytes())
+	require.NoError(h.T, err)
+	h.Commit()
+	return id
+}
+
+// PerformUpkeep simulates the keeper node performing a registered upkeep.
+func (h *Harness) PerformUpkeep(id *big.Int, performData []byte) {
+	_, err := h.Registry.SimulatePerformUpkeep(&bind.CallOpts{}, id, performData)
+	require.NoError(h.T, err)
+	h.Commit()
+}
+
+// CancelUpkeep cancels a registered upkeep so its membership in the active
+// set can be asserted to drop out on the next refresh.
+func (h *Harness) CancelUpkeep(id *big.Int) {
+	_, err := h.Registry.CancelUpkeep(h.Owner, id)
+	require.NoError(h.T, err)
+	h.Commit()
+}
+
+const (
+	conditionTriggerType uint8 = 0
+	logTriggerType       uint8 = 1
+)
+
+func (h *Harness) registerUpkeep(target common.Address, gasLimit uint32, triggerType uint8) *big.Int {
+	tx, err := h.Registry.RegisterUpkeep(h.Owner, target, gasLimit, h.Owner.From, triggerType, nil)
+	require.NoError(h.T, err)
+	h.Commit()
+	receipt, err := h.Backend.TransactionReceipt(nil, tx.Hash())
+	require.NoError(h.T, err)
+
+	id := h.parseRegisteredUpkeepID(receipt.Logs)
+	require.NotNil(h.T, id, "RegisterUpkeep receipt did not contain an UpkeepRegistered log")
+	return id
+}
+
+// parseRegisteredUpkeepID finds the UpkeepRegistered event among a
+// receipt's logs and returns its upkeep ID. abigen's generated Parse*
+// methods take a single log, not a receipt, so each log in the receipt is
+// tried until one parses.
+func (h *Harness) parseRegisteredUpkeepID(logs []*types.Log) *big.Int {
+	for _, vLog := range logs {
+		registered, err := h.Registry.ParseUpkeepRegistered(*vLog)
+		if err != nil {
+			continue
+		}
+		return registered.Id
+	}
+	return nil
+}
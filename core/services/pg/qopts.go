@@ -0,0 +1,35 @@
+package pg
+
+import "context"
+
+// QOpt configures a query, most commonly to bind it to a parent context so
+// its lifetime is tied to the caller's rather than running unbounded.
+type QOpt func(*QueryConfig)
+
+// QueryConfig is the result of applying a set of QOpts to a query.
+type QueryConfig struct {
+	ctx context.Context
+}
+
+// WithParentCtx binds a query to ctx, so cancelling ctx cancels the query.
+func WithParentCtx(ctx context.Context) QOpt {
+	return func(c *QueryConfig) { c.ctx = ctx }
+}
+
+// NewQueryConfig applies qopts in order and returns the resulting config.
+func NewQueryConfig(qopts ...QOpt) QueryConfig {
+	var c QueryConfig
+	for _, opt := range qopts {
+		opt(&c)
+	}
+	return c
+}
+
+// Context returns the bound context, or context.Background() if none of
+// qopts set one.
+func (c QueryConfig) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}